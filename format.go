@@ -0,0 +1,91 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// formatExtensible is the WAVE_FORMAT_EXTENSIBLE format tag. A fmt chunk
+// using it carries the real sample format (formatPCM or formatIEEEFloat) in
+// its SubFormat GUID instead of in audioFormat.
+const formatExtensible uint16 = 0xFFFE
+
+// subformatTail is the fixed portion of the KSDATAFORMAT_SUBTYPE_* GUIDs
+// (everything after Data1, which holds the format tag): 0000-0010-8000-00AA00389B71.
+var subformatTail = [12]byte{0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// Conventional WAVE_FORMAT_EXTENSIBLE speaker positions, used to build
+// dwChannelMask values. See the Windows SPEAKER_* constants.
+const (
+	SpeakerFrontLeft    uint32 = 0x1
+	SpeakerFrontRight   uint32 = 0x2
+	SpeakerFrontCenter  uint32 = 0x4
+	SpeakerLowFrequency uint32 = 0x8
+	SpeakerBackLeft     uint32 = 0x10
+	SpeakerBackRight    uint32 = 0x20
+	SpeakerSideLeft     uint32 = 0x200
+	SpeakerSideRight    uint32 = 0x400
+)
+
+// ChannelMaskFor returns the conventional WAVE_FORMAT_EXTENSIBLE channel
+// mask for common speaker layouts, keyed by channel count: mono, stereo,
+// 5.1, and 7.1. Other channel counts return 0, which tells players to fall
+// back to their default speaker ordering.
+func ChannelMaskFor(channels uint16) uint32 {
+	switch channels {
+	case 1:
+		return SpeakerFrontCenter
+	case 2:
+		return SpeakerFrontLeft | SpeakerFrontRight
+	case 6:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency | SpeakerBackLeft | SpeakerBackRight
+	case 8:
+		return SpeakerFrontLeft | SpeakerFrontRight | SpeakerFrontCenter | SpeakerLowFrequency | SpeakerBackLeft | SpeakerBackRight | SpeakerSideLeft | SpeakerSideRight
+	default:
+		return 0
+	}
+}
+
+// extensibleExtension builds the 24-byte WAVE_FORMAT_EXTENSIBLE tail: cbSize,
+// wValidBitsPerSample, dwChannelMask, and the SubFormat GUID for subFormat
+// (formatPCM or formatIEEEFloat).
+func extensibleExtension(bitsPerSample uint8, channelMask uint32, subFormat uint16) []byte {
+	buffer := new(bytes.Buffer)
+	binary.Write(buffer, binary.LittleEndian, uint16(22)) // cbSize: fixed part of WAVEFORMATEXTENSIBLE
+	binary.Write(buffer, binary.LittleEndian, uint16(bitsPerSample))
+	binary.Write(buffer, binary.LittleEndian, channelMask)
+	binary.Write(buffer, binary.LittleEndian, uint32(subFormat))
+	buffer.Write(subformatTail[:])
+	return buffer.Bytes()
+}
+
+// newExtensibleWavfileHeader builds a header using the WAVE_FORMAT_EXTENSIBLE
+// fmt chunk, needed for channel layouts beyond stereo and for sample formats
+// that a plain 16-byte fmt chunk cannot describe unambiguously.
+func newExtensibleWavfileHeader(samplesPerSecond uint32, bitsPerSample uint8, channels uint16, channelMask uint32, subFormat uint16) *wavfileHeader {
+	header := newWavfileHeader(samplesPerSecond, bitsPerSample, channels)
+	header.audioFormat = formatExtensible
+	header.extension = extensibleExtension(bitsPerSample, channelMask, subFormat)
+	header.fmtLength = uint32(16 + len(header.extension))
+	return header
+}
+
+// NewFloat32 creates a WAV file of 32-bit IEEE float samples, using a
+// WAVE_FORMAT_EXTENSIBLE header so players that key off the SubFormat GUID
+// recognize the data as float rather than integer PCM. mode controls RF64
+// promotion; it defaults to RF64Auto if omitted.
+func NewFloat32(w io.WriterAt, samplesPerSecond uint32, channels uint16, waveform []byte, mode ...RF64Mode) error {
+	header := newExtensibleWavfileHeader(samplesPerSecond, 32, channels, ChannelMaskFor(channels), formatIEEEFloat)
+	return writeWavFile(w, header, waveform, rf64ModeOrDefault(mode))
+}
+
+// NewExtensible creates a WAV file with an explicit WAVE_FORMAT_EXTENSIBLE
+// header and channelMask, needed for bit depths such as 24-bit and for
+// channel layouts beyond stereo (5.1, 7.1, ...) that many players otherwise
+// misinterpret under the plain fmt chunk. mode controls RF64 promotion; it
+// defaults to RF64Auto if omitted.
+func NewExtensible(w io.WriterAt, samplesPerSecond uint32, bitsPerSample uint8, channels uint16, channelMask uint32, waveform []byte, mode ...RF64Mode) error {
+	header := newExtensibleWavfileHeader(samplesPerSecond, bitsPerSample, channels, channelMask, formatPCM)
+	return writeWavFile(w, header, waveform, rf64ModeOrDefault(mode))
+}
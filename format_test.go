@@ -0,0 +1,149 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestNewFloat32RoundTrip(t *testing.T) {
+	mw := &memWriterAt{}
+	waveform := PackFloat32LE([]float32{1, -1, 0.5})
+	if err := NewFloat32(mw, 48000, 1, waveform); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, bits, ch, af := r.Format()
+	if sr != 48000 || bits != 32 || ch != 1 || af != formatIEEEFloat {
+		t.Fatalf("Format() = %d, %d, %d, %d; want 48000, 32, 1, %d", sr, bits, ch, af, formatIEEEFloat)
+	}
+
+	dst := make([]int32, 3)
+	if _, err := r.ReadSamples(dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst[0] != math.MaxInt32 {
+		t.Errorf("dst[0] = %d, want %d", dst[0], math.MaxInt32)
+	}
+	if dst[1] != -math.MaxInt32 {
+		t.Errorf("dst[1] = %d, want %d", dst[1], -math.MaxInt32)
+	}
+}
+
+func TestNewExtensiblePreservesChannelLayout(t *testing.T) {
+	mw := &memWriterAt{}
+	waveform := PackInt24LE(make([]int32, 6*10)) // 10 frames of 5.1
+	mask := ChannelMaskFor(6)
+	if err := NewExtensible(mw, 48000, 24, 6, mask, waveform); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, bits, ch, af := r.Format()
+	if sr != 48000 || bits != 24 || ch != 6 || af != formatPCM {
+		t.Fatalf("Format() = %d, %d, %d, %d; want 48000, 24, 6, %d", sr, bits, ch, af, formatPCM)
+	}
+
+	// dwChannelMask sits 4 bytes into the extension, which starts at offset 36.
+	if got := binary.LittleEndian.Uint32(mw.buf[40:44]); got != mask {
+		t.Errorf("dwChannelMask = %#x, want %#x", got, mask)
+	}
+}
+
+// TestNewExtensibleValidBitsNarrowerThanContainer is a regression test:
+// readFmtChunk used to overwrite bitsPerSample with wValidBitsPerSample,
+// which is routinely smaller than the container width (e.g. 20-bit-valid
+// audio padded into a 24-bit container). That broke the frame byte-stride,
+// desyncing every sample.
+func TestNewExtensibleValidBitsNarrowerThanContainer(t *testing.T) {
+	mw := &memWriterAt{}
+	waveform := PackInt24LE([]int32{100, -100, 200})
+	header := newExtensibleWavfileHeader(44100, 24, 1, ChannelMaskFor(1), formatPCM)
+	binary.LittleEndian.PutUint16(header.extension[2:4], 20) // wValidBitsPerSample
+	if err := writeWavFile(mw, header, waveform, RF64Auto); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bits, _, _ := r.Format()
+	if bits != 24 {
+		t.Fatalf("Format() bits = %d, want 24 (container width, not validBits)", bits)
+	}
+
+	dst := make([]int32, 3)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int32{100, -100, 200}
+	if n != len(want) {
+		t.Fatalf("ReadSamples() read %d samples, want %d", n, len(want))
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Errorf("sample %d = %d, want %d", i, dst[i], want[i])
+		}
+	}
+}
+
+// TestNewExtensibleValidBits16InContainer24 covers the worse failure mode of
+// the same bug: a 16-bit validBits value silently switched ReadSamples to a
+// 2-byte stride instead of the container's 3, desyncing every frame with no
+// error at all.
+func TestNewExtensibleValidBits16InContainer24(t *testing.T) {
+	mw := &memWriterAt{}
+	waveform := PackInt24LE([]int32{100, -100, 200})
+	header := newExtensibleWavfileHeader(44100, 24, 1, ChannelMaskFor(1), formatPCM)
+	binary.LittleEndian.PutUint16(header.extension[2:4], 16) // wValidBitsPerSample
+	if err := writeWavFile(mw, header, waveform, RF64Auto); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bits, _, _ := r.Format()
+	if bits != 24 {
+		t.Fatalf("Format() bits = %d, want 24 (container width, not validBits)", bits)
+	}
+
+	dst := make([]int32, 3)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int32{100, -100, 200}
+	if n != len(want) {
+		t.Fatalf("ReadSamples() read %d samples, want %d", n, len(want))
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Errorf("sample %d = %d, want %d", i, dst[i], want[i])
+		}
+	}
+}
+
+func TestChannelMaskFor(t *testing.T) {
+	cases := map[uint16]uint32{
+		1: SpeakerFrontCenter,
+		2: SpeakerFrontLeft | SpeakerFrontRight,
+		3: 0,
+	}
+	for channels, want := range cases {
+		if got := ChannelMaskFor(channels); got != want {
+			t.Errorf("ChannelMaskFor(%d) = %#x, want %#x", channels, got, want)
+		}
+	}
+}
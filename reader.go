@@ -0,0 +1,244 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Audio format tags recognized in the fmt chunk's AudioFormat field.
+const (
+	formatPCM       uint16 = 1
+	formatIEEEFloat uint16 = 3
+)
+
+// Reader parses an existing RIFF/WAVE file (a big-endian RIFX file, per the
+// byte-order note on wavfileHeader, or an RF64/BW64 file) and streams its
+// PCM frames.
+type Reader struct {
+	r             io.Reader
+	order         binary.ByteOrder
+	sampleRate    uint32
+	bitsPerSample uint8
+	channels      uint16
+	audioFormat   uint16
+	dataRemaining uint64 // bytes left in the current data chunk
+}
+
+// NewReader reads the RIFF/RIFX/RF64 header and walks chunks, skipping
+// anything that isn't ds64, fmt or data (LIST, bext, cue , fact, ...), until
+// it has seen a fmt chunk and reaches data. The returned Reader is
+// positioned at the start of the audio data.
+func NewReader(r io.Reader) (*Reader, error) {
+	var tag [4]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	var isRF64 bool
+	switch string(tag[:]) {
+	case "RIFF":
+		order = binary.LittleEndian
+	case "RIFX":
+		order = binary.BigEndian
+	case "RF64", "BW64":
+		order = binary.LittleEndian
+		isRF64 = true
+	default:
+		return nil, fmt.Errorf("wav: not a RIFF/RIFX/RF64 file: %q", tag[:])
+	}
+
+	// RIFF chunk size; for RF64 this is the 0xFFFFFFFF sentinel and the
+	// true size, if needed, would come from the ds64 chunk's riffSize.
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return nil, err
+	}
+	if string(tag[:]) != "WAVE" {
+		return nil, fmt.Errorf("wav: not a WAVE file: %q", tag[:])
+	}
+
+	rd := &Reader{r: r, order: order}
+
+	var gotFmt bool
+	var ds64DataSize uint64
+	for {
+		var id [4]byte
+		if _, err := io.ReadFull(r, id[:]); err != nil {
+			return nil, err
+		}
+		var size uint32
+		if err := binary.Read(r, order, &size); err != nil {
+			return nil, err
+		}
+
+		switch string(id[:]) {
+		case "ds64":
+			var err error
+			ds64DataSize, err = rd.readDs64Chunk(size)
+			if err != nil {
+				return nil, err
+			}
+		case "fmt ":
+			if err := rd.readFmtChunk(size); err != nil {
+				return nil, err
+			}
+			gotFmt = true
+		case "data":
+			if !gotFmt {
+				return nil, errors.New("wav: data chunk before fmt chunk")
+			}
+			if isRF64 && size == 0xFFFFFFFF {
+				rd.dataRemaining = ds64DataSize
+			} else {
+				rd.dataRemaining = uint64(size)
+			}
+			return rd, nil
+		default:
+			if err := skipChunk(r, size); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readDs64Chunk reads an RF64 ds64 chunk and returns its 64-bit dataSize,
+// which is what the 32-bit "data" chunk size field is a sentinel for.
+func (rd *Reader) readDs64Chunk(size uint32) (uint64, error) {
+	if size < ds64FieldsSize {
+		return 0, fmt.Errorf("wav: ds64 chunk too small: %d bytes", size)
+	}
+	buf := make([]byte, size+(size&1))
+	if _, err := io.ReadFull(rd.r, buf); err != nil {
+		return 0, err
+	}
+	// layout: riffSize(8), dataSize(8), sampleCount(8), tableLength(4), ...
+	return rd.order.Uint64(buf[8:16]), nil
+}
+
+// skipChunk discards a chunk's body, honoring the RIFF rule that chunks are
+// padded to an even number of bytes.
+func skipChunk(r io.Reader, size uint32) error {
+	n := int64(size) + int64(size&1)
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+func (rd *Reader) readFmtChunk(size uint32) error {
+	if size < 16 {
+		return fmt.Errorf("wav: fmt chunk too small: %d bytes", size)
+	}
+	buf := make([]byte, size+(size&1))
+	if _, err := io.ReadFull(rd.r, buf); err != nil {
+		return err
+	}
+
+	rd.audioFormat = rd.order.Uint16(buf[0:2])
+	rd.channels = rd.order.Uint16(buf[2:4])
+	rd.sampleRate = rd.order.Uint32(buf[4:8])
+	rd.bitsPerSample = uint8(rd.order.Uint16(buf[14:16]))
+
+	if rd.audioFormat == formatExtensible && len(buf) >= 16+24 {
+		// WAVE_FORMAT_EXTENSIBLE: the real sample format lives in the
+		// extension's SubFormat GUID (Data1), not in audioFormat above.
+		// The extension's wValidBitsPerSample is deliberately ignored here:
+		// it only says how many of the container's bits are meaningful
+		// (e.g. 20-bit-valid audio padded into a 24-bit container) and must
+		// never replace bitsPerSample, which is the fixed field at
+		// buf[14:16] and is what determines the frame byte-stride below.
+		ext := buf[16:]
+		rd.audioFormat = rd.order.Uint16(ext[8:10])
+	}
+	return nil
+}
+
+// Format reports the audio parameters read from the fmt chunk.
+func (rd *Reader) Format() (sampleRate uint32, bitsPerSample uint8, channels uint16, audioFormat uint16) {
+	return rd.sampleRate, rd.bitsPerSample, rd.channels, rd.audioFormat
+}
+
+// ReadSamples decodes interleaved PCM frames from the data chunk into dst,
+// converting 8/16/24/32-bit little- or big-endian integer PCM and 32-bit
+// IEEE float samples to int32, and returns the number of samples read. It
+// returns io.EOF once the data chunk is exhausted.
+func (rd *Reader) ReadSamples(dst []int32) (int, error) {
+	frameSize := int(rd.bitsPerSample) / 8
+	if frameSize == 0 {
+		return 0, errors.New("wav: unknown bits per sample")
+	}
+
+	buf := make([]byte, frameSize)
+	n := 0
+	for n < len(dst) {
+		if rd.dataRemaining < uint64(frameSize) {
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		}
+		if _, err := io.ReadFull(rd.r, buf); err != nil {
+			if n == 0 {
+				return 0, err
+			}
+			return n, nil
+		}
+		rd.dataRemaining -= uint64(frameSize)
+
+		switch {
+		case rd.audioFormat == formatIEEEFloat && rd.bitsPerSample == 32:
+			f := math.Float32frombits(rd.order.Uint32(buf))
+			dst[n] = scaleFloatToInt32(f)
+		case rd.bitsPerSample == 8:
+			dst[n] = int32(buf[0]) - 128
+		case rd.bitsPerSample == 16:
+			dst[n] = int32(int16(rd.order.Uint16(buf)))
+		case rd.bitsPerSample == 24:
+			dst[n] = decode24(buf, rd.order)
+		case rd.bitsPerSample == 32:
+			dst[n] = int32(rd.order.Uint32(buf))
+		default:
+			return n, fmt.Errorf("wav: unsupported bits per sample: %d", rd.bitsPerSample)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// scaleFloatToInt32 converts a [-1, 1]-range IEEE float sample to int32,
+// clamping samples outside that range (whose product exceeds the int32
+// range) rather than letting the conversion overflow.
+func scaleFloatToInt32(f float32) int32 {
+	v := float64(f) * math.MaxInt32
+	switch {
+	case v > math.MaxInt32:
+		return math.MaxInt32
+	case v < math.MinInt32:
+		return math.MinInt32
+	default:
+		return int32(v)
+	}
+}
+
+// decode24 sign-extends a 3-byte PCM sample to int32.
+func decode24(buf []byte, order binary.ByteOrder) int32 {
+	var v int32
+	if order == binary.BigEndian {
+		v = int32(buf[2]) | int32(buf[1])<<8 | int32(buf[0])<<16
+	} else {
+		v = int32(buf[0]) | int32(buf[1])<<8 | int32(buf[2])<<16
+	}
+	if v&0x800000 != 0 {
+		v |= ^int32(0xFFFFFF)
+	}
+	return v
+}
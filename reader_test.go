@@ -0,0 +1,114 @@
+package wav
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReaderRIFX(t *testing.T) {
+	mw := &memWriterAt{}
+	if err := New(mw, 44100, 16, 1, PackInt16LE([]int16{100, -100})); err != nil {
+		t.Fatal(err)
+	}
+
+	// New only ever writes little-endian RIFF; flip the outer tag to RIFX
+	// and byte-swap every multi-byte field to synthesize a big-endian file.
+	raw := append([]byte(nil), mw.buf...)
+	copy(raw[0:4], "RIFX")
+	swap32(raw[4:8])
+	swap32(raw[16:20]) // fmtLength
+	swap16(raw[20:22]) // audioFormat
+	swap16(raw[22:24]) // numChannels
+	swap32(raw[24:28]) // sampleRate
+	swap32(raw[28:32]) // byteRate
+	swap16(raw[32:34]) // blockAlign
+	swap16(raw[34:36]) // bitsPerSample
+	swap32(raw[40:44]) // dataLength
+	swap16(raw[44:46])
+	swap16(raw[46:48])
+
+	r, err := NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, bits, ch, _ := r.Format()
+	if sr != 44100 || bits != 16 || ch != 1 {
+		t.Fatalf("Format() = %d, %d, %d; want 44100, 16, 1", sr, bits, ch)
+	}
+
+	dst := make([]int32, 2)
+	if _, err := r.ReadSamples(dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst[0] != 100 || dst[1] != -100 {
+		t.Errorf("samples = %v, want [100 -100]", dst)
+	}
+}
+
+func TestReaderSkipsUnknownChunks(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	buf.Write(u32le(0)) // riffLength, unused by the reader
+
+	buf.WriteString("WAVE")
+
+	// A LIST chunk with an odd size, to exercise the padding-byte rule.
+	buf.WriteString("LIST")
+	buf.Write(u32le(5))
+	buf.Write([]byte{1, 2, 3, 4, 5, 0}) // + 1 pad byte
+
+	fmtChunk := newWavfileHeader(8000, 16, 1).fmtChunkBytes()
+	buf.Write(fmtChunk)
+
+	buf.WriteString("data")
+	buf.Write(u32le(4))
+	buf.Write(PackInt16LE([]int16{7, -7}))
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]int32, 2)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || dst[0] != 7 || dst[1] != -7 {
+		t.Errorf("ReadSamples() = %d, %v; want 2, [7 -7]", n, dst)
+	}
+}
+
+func TestReaderEOFAtDataEnd(t *testing.T) {
+	mw := &memWriterAt{}
+	if err := New(mw, 8000, 16, 1, PackInt16LE([]int16{1, 2})); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]int32, 3)
+	n, err := r.ReadSamples(dst)
+	if n != 2 {
+		t.Errorf("n = %d, want 2", n)
+	}
+	if err != nil {
+		t.Errorf("first ReadSamples() err = %v, want nil (partial read)", err)
+	}
+	if _, err := r.ReadSamples(dst); err != io.EOF {
+		t.Errorf("second ReadSamples() err = %v, want io.EOF", err)
+	}
+}
+
+func u32le(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
+
+func swap16(b []byte) {
+	b[0], b[1] = b[1], b[0]
+}
+
+func swap32(b []byte) {
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+}
@@ -0,0 +1,86 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// RF64Mode controls when output is promoted from the standard 32-bit
+// RIFF/WAVE header to RF64/BW64, whose 64-bit ds64 chunk removes the ~4 GiB
+// cap that riffLength and dataLength impose.
+type RF64Mode int
+
+const (
+	// RF64Auto promotes to RF64 only when the RIFF/data sizes would
+	// otherwise overflow 32 bits. This is the default.
+	RF64Auto RF64Mode = iota
+	// RF64Always always writes the RF64 form, even for small files.
+	RF64Always
+	// RF64Never never promotes, even if the resulting sizes overflow;
+	// callers that choose this accept a technically invalid oversized file.
+	RF64Never
+)
+
+// maxRIFFSize is the largest value a 32-bit RIFF/data chunk size field can
+// hold without colliding with the 0xFFFFFFFF RF64 sentinel.
+const maxRIFFSize = 0xFFFFFFFE
+
+// ds64FieldsSize is the size of a ds64 chunk's body with no size-table
+// entries: riffSize(8) + dataSize(8) + sampleCount(8) + tableLength(4).
+const ds64FieldsSize = 28
+
+// ds64Bytes builds a complete "ds64" chunk (tag, size, and body) carrying
+// the real 64-bit riffSize, dataSize and sampleCount for an RF64 file. It
+// has no size-table entries, since a plain PCM/float file has only the one
+// oversized chunk (data), already covered by dataSize.
+func ds64Bytes(riffSize, dataSize, sampleCount uint64) []byte {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("ds64")
+	binary.Write(buffer, binary.LittleEndian, uint32(ds64FieldsSize))
+	binary.Write(buffer, binary.LittleEndian, riffSize)
+	binary.Write(buffer, binary.LittleEndian, dataSize)
+	binary.Write(buffer, binary.LittleEndian, sampleCount)
+	binary.Write(buffer, binary.LittleEndian, uint32(0)) // tableLength: no extra entries
+	return buffer.Bytes()
+}
+
+// needsRF64 reports whether a file with the given fmt chunk and data size
+// would overflow the 32-bit RIFF/data size fields. The RIFF size it checks
+// includes the ds64 chunk of the RF64 form, so it reports true from 36
+// bytes below the point where a plain RIFF file would overflow.
+func needsRF64(fmtChunk []byte, dataSize uint64) bool {
+	riffSize := uint64(4) + uint64(8+ds64FieldsSize) + uint64(len(fmtChunk)) + uint64(8) + dataSize
+	return riffSize > maxRIFFSize
+}
+
+// writeRF64File writes an RF64-framed file: "RF64" + size sentinel + "WAVE"
+// + ds64 (carrying the real sizes) + the fmt chunk + the data chunk (also
+// size-sentineled) + waveform. Unlike the plain RIFF path, the real sizes
+// are known up front here, so there is nothing left to patch afterwards.
+func writeRF64File(w io.WriterAt, header *wavfileHeader, waveform []byte) error {
+	fmtChunk := header.fmtChunkBytes()
+	dataSize := uint64(len(waveform))
+
+	var sampleCount uint64
+	if header.blockAlign > 0 {
+		sampleCount = dataSize / uint64(header.blockAlign)
+	}
+	riffSize := uint64(4) + uint64(8+ds64FieldsSize) + uint64(len(fmtChunk)) + uint64(8) + dataSize
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("RF64")
+	binary.Write(buffer, binary.LittleEndian, uint32(0xFFFFFFFF))
+	buffer.WriteString("WAVE")
+	buffer.Write(ds64Bytes(riffSize, dataSize, sampleCount))
+	buffer.Write(fmtChunk)
+	buffer.Write(header.dataTag[:])
+	binary.Write(buffer, binary.LittleEndian, uint32(0xFFFFFFFF))
+
+	headerBytes := buffer.Bytes()
+	if _, err := w.WriteAt(headerBytes, 0); err != nil {
+		return err
+	}
+	_, err := w.WriteAt(waveform, int64(len(headerBytes)))
+	return err
+}
@@ -0,0 +1,115 @@
+package wav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewRF64AlwaysRoundTrip(t *testing.T) {
+	mw := &memWriterAt{}
+	waveform := PackInt16LE([]int16{10, -10, 20, -20})
+	if err := New(mw, 44100, 16, 2, waveform, RF64Always); err != nil {
+		t.Fatal(err)
+	}
+	if string(mw.buf[0:4]) != "RF64" {
+		t.Fatalf("tag = %q, want RF64", mw.buf[0:4])
+	}
+	if string(mw.buf[8:12]) != "WAVE" || string(mw.buf[12:16]) != "ds64" {
+		t.Fatalf("expected WAVE/ds64 chunks right after the RF64 tag, got %q/%q", mw.buf[8:12], mw.buf[12:16])
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]int32, 4)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int32{10, -10, 20, -20}
+	if n != len(want) {
+		t.Fatalf("n = %d, want %d", n, len(want))
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Errorf("sample %d = %d, want %d", i, dst[i], want[i])
+		}
+	}
+}
+
+func TestWriterRF64PlaceholderWriterAt(t *testing.T) {
+	mw := &memWriterAt{}
+	w, err := NewWriter(mw, 44100, 16, 1, WithRF64(RF64Always))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteSamples([]int32{1, 2, 3, 4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(mw.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]int32, 5)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("n = %d, want 5", n)
+	}
+	for i, want := range []int32{1, 2, 3, 4, 5} {
+		if dst[i] != want {
+			t.Errorf("sample %d = %d, want %d", i, dst[i], want)
+		}
+	}
+}
+
+// TestWriterRF64PlaceholderSeekerOnly exercises the io.Seeker fallback in
+// patchRF64Sizes (no io.WriterAt available).
+func TestWriterRF64PlaceholderSeekerOnly(t *testing.T) {
+	sw := &seekWriter{}
+	w, err := NewWriter(sw, 44100, 16, 1, WithRF64(RF64Always))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteSamples([]int32{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(sw.mem.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]int32, 3)
+	n, err := r.ReadSamples(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || dst[0] != 1 || dst[1] != 2 || dst[2] != 3 {
+		t.Errorf("ReadSamples() = %d, %v; want 3, [1 2 3]", n, dst)
+	}
+}
+
+func TestNeedsRF64Threshold(t *testing.T) {
+	fmtChunk := newWavfileHeader(44100, 16, 2).fmtChunkBytes()
+	// Mirrors needsRF64's own riffSize formula: WAVE + ds64 chunk + fmt
+	// chunk + data tag/size.
+	overhead := uint64(4) + uint64(8+ds64FieldsSize) + uint64(len(fmtChunk)) + uint64(8)
+
+	justFits := maxRIFFSize - overhead
+	if needsRF64(fmtChunk, justFits) {
+		t.Errorf("needsRF64(%d) = true, want false (just fits)", justFits)
+	}
+	if !needsRF64(fmtChunk, justFits+1) {
+		t.Errorf("needsRF64(%d) = false, want true (one byte over)", justFits+1)
+	}
+}
@@ -0,0 +1,168 @@
+package wav
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// PackInt16LE packs 16-bit samples into little-endian bytes suitable for
+// New's waveform argument or Writer.Write.
+func PackInt16LE(samples []int16) []byte {
+	buf := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(buf[i*2:], uint16(s))
+	}
+	return buf
+}
+
+// UnpackInt16LE is the inverse of PackInt16LE.
+func UnpackInt16LE(buf []byte) []int16 {
+	samples := make([]int16, len(buf)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(buf[i*2:]))
+	}
+	return samples
+}
+
+// PackInt24LE packs 24-bit samples, held in the low 24 bits of each int32,
+// into little-endian bytes.
+func PackInt24LE(samples []int32) []byte {
+	buf := make([]byte, len(samples)*3)
+	for i, s := range samples {
+		v := uint32(s)
+		buf[i*3] = byte(v)
+		buf[i*3+1] = byte(v >> 8)
+		buf[i*3+2] = byte(v >> 16)
+	}
+	return buf
+}
+
+// UnpackInt24LE is the inverse of PackInt24LE, sign-extending each 3-byte
+// sample into an int32.
+func UnpackInt24LE(buf []byte) []int32 {
+	samples := make([]int32, len(buf)/3)
+	for i := range samples {
+		samples[i] = decode24(buf[i*3:i*3+3], binary.LittleEndian)
+	}
+	return samples
+}
+
+// PackFloat32LE packs 32-bit IEEE float samples into little-endian bytes.
+func PackFloat32LE(samples []float32) []byte {
+	buf := make([]byte, len(samples)*4)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(s))
+	}
+	return buf
+}
+
+// UnpackFloat32LE is the inverse of PackFloat32LE.
+func UnpackFloat32LE(buf []byte) []float32 {
+	samples := make([]float32, len(buf)/4)
+	for i := range samples {
+		samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return samples
+}
+
+// Waveform selects the shape an Oscillator generates.
+type Waveform int
+
+const (
+	Sine Waveform = iota
+	Square
+	Saw
+	Triangle
+)
+
+// Oscillator generates a periodic signal of the given Waveform, sampled at
+// SampleRate, producing values in [-Amplitude, Amplitude].
+type Oscillator struct {
+	Waveform   Waveform
+	SampleRate uint32
+	Frequency  float64
+	Amplitude  float64
+
+	phase float64 // radians, advances each Next call
+}
+
+// NewOscillator creates an Oscillator starting at the given phase, in
+// radians.
+func NewOscillator(waveform Waveform, sampleRate uint32, frequency, amplitude, phase float64) *Oscillator {
+	return &Oscillator{
+		Waveform:   waveform,
+		SampleRate: sampleRate,
+		Frequency:  frequency,
+		Amplitude:  amplitude,
+		phase:      phase,
+	}
+}
+
+// Next returns the oscillator's next sample and advances its phase by one
+// sample period.
+func (o *Oscillator) Next() float64 {
+	const twoPi = 2 * math.Pi
+	phase := math.Mod(o.phase, twoPi)
+	if phase < 0 {
+		phase += twoPi
+	}
+	frac := phase / twoPi // 0..1 fraction of the current cycle
+
+	var v float64
+	switch o.Waveform {
+	case Square:
+		if frac < 0.5 {
+			v = 1
+		} else {
+			v = -1
+		}
+	case Saw:
+		v = 2*frac - 1
+	case Triangle:
+		v = 4*math.Abs(frac-0.5) - 1
+	default: // Sine
+		v = math.Sin(phase)
+	}
+
+	o.phase += twoPi * o.Frequency / float64(o.SampleRate)
+	return v * o.Amplitude
+}
+
+// Generate returns the next n consecutive samples.
+func (o *Oscillator) Generate(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = o.Next()
+	}
+	return out
+}
+
+// ExponentialDecay is an envelope that decays following e^(-t/Tau), useful
+// for shaping an Oscillator's output into a plucked or struck note.
+type ExponentialDecay struct {
+	SampleRate uint32
+	Tau        float64 // time constant in seconds
+
+	t float64
+}
+
+// NewExponentialDecay creates an ExponentialDecay starting at t=0, where it
+// evaluates to 1.
+func NewExponentialDecay(sampleRate uint32, tau float64) *ExponentialDecay {
+	return &ExponentialDecay{SampleRate: sampleRate, Tau: tau}
+}
+
+// Next returns the envelope's current value and advances time by one
+// sample period.
+func (e *ExponentialDecay) Next() float64 {
+	v := math.Exp(-e.t / e.Tau)
+	e.t += 1 / float64(e.SampleRate)
+	return v
+}
+
+// Apply multiplies each sample in place by successive envelope values.
+func (e *ExponentialDecay) Apply(samples []float64) {
+	for i := range samples {
+		samples[i] *= e.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPackUnpackInt16LERoundTrip(t *testing.T) {
+	samples := []int16{0, 1, -1, math.MaxInt16, math.MinInt16}
+	got := UnpackInt16LE(PackInt16LE(samples))
+	for i, want := range samples {
+		if got[i] != want {
+			t.Errorf("sample %d = %d, want %d", i, got[i], want)
+		}
+	}
+}
+
+func TestPackUnpackInt24LERoundTrip(t *testing.T) {
+	samples := []int32{0, 1, -1, 1<<23 - 1, -(1 << 23)}
+	got := UnpackInt24LE(PackInt24LE(samples))
+	for i, want := range samples {
+		if got[i] != want {
+			t.Errorf("sample %d = %d, want %d", i, got[i], want)
+		}
+	}
+}
+
+func TestPackUnpackFloat32LERoundTrip(t *testing.T) {
+	samples := []float32{0, 1, -1, 0.5, -0.5}
+	got := UnpackFloat32LE(PackFloat32LE(samples))
+	for i, want := range samples {
+		if got[i] != want {
+			t.Errorf("sample %d = %g, want %g", i, got[i], want)
+		}
+	}
+}
+
+func TestOscillatorWaveformsStayInRange(t *testing.T) {
+	for _, wf := range []Waveform{Sine, Square, Saw, Triangle} {
+		o := NewOscillator(wf, 8000, 440, 1, 0)
+		for i, v := range o.Generate(8000) {
+			if v > 1 || v < -1 {
+				t.Fatalf("waveform %d sample %d = %g, want within [-1, 1]", wf, i, v)
+			}
+		}
+	}
+}
+
+// TestOscillatorNegativePhaseStaysInRange is a regression test: Next used to
+// evaluate frac := o.phase / twoPi without normalizing a negative starting
+// phase, so Square/Saw/Triangle could emit values outside [-Amplitude,
+// Amplitude].
+func TestOscillatorNegativePhaseStaysInRange(t *testing.T) {
+	for _, wf := range []Waveform{Sine, Square, Saw, Triangle} {
+		o := NewOscillator(wf, 8000, 440, 1, -10*math.Pi)
+		for i, v := range o.Generate(100) {
+			if v > 1 || v < -1 {
+				t.Errorf("waveform %d sample %d = %g, want within [-1, 1]", wf, i, v)
+			}
+		}
+	}
+}
+
+func TestExponentialDecayDecreasesTowardZero(t *testing.T) {
+	e := NewExponentialDecay(8000, 0.01)
+	prev := e.Next()
+	if prev != 1 {
+		t.Fatalf("first value = %g, want 1", prev)
+	}
+	for i := 0; i < 800; i++ { // 0.1s, 10 time constants
+		v := e.Next()
+		if v >= prev {
+			t.Fatalf("sample %d = %g, want less than previous %g", i, v, prev)
+		}
+		prev = v
+	}
+	if prev > 0.01 {
+		t.Errorf("value after 10 time constants = %g, want close to 0", prev)
+	}
+}
+
+func TestExponentialDecayApply(t *testing.T) {
+	e := NewExponentialDecay(8000, 0.1)
+	samples := []float64{1, 1, 1, 1}
+	e.Apply(samples)
+	if samples[0] != 1 {
+		t.Errorf("samples[0] = %g, want 1", samples[0])
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i] >= samples[i-1] {
+			t.Errorf("samples[%d] = %g, want less than samples[%d] = %g", i, samples[i], i-1, samples[i-1])
+		}
+	}
+}
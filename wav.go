@@ -64,11 +64,14 @@ type wavfileHeader struct {
 	// 8 bits = 8, 16 bits = 16
 	bitsPerSample uint16 // BitsPerSample 2 bytes
 
-	// Offset 36
+	// extension holds the WAVE_FORMAT_EXTENSIBLE tail (cbSize,
+	// wValidBitsPerSample, dwChannelMask, SubFormat), appended after
+	// bitsPerSample and counted in fmtLength. Nil for a plain fmt chunk.
+	extension []byte
+
 	// Contains the letters "data"
 	dataTag [4]uint8 // Subchunk2ID 4 bytes
 
-	// Offset 40
 	// This is the number of bytes in the data.
 	// = NumSamples * NumChannels * BitsPerSample/8
 	dataLength uint32 // Subchunk2Size 4 bytes
@@ -92,36 +95,84 @@ func newWavfileHeader(samplesPerSecond uint32, bitsPerSample uint8, channels uin
 	return header
 }
 
-// Convert the wavfileHeader struct to a byte slice
+// fmtChunkBytes returns just the "fmt " sub-chunk (tag, size, the fixed
+// fields, and the WAVE_FORMAT_EXTENSIBLE extension if present), without the
+// surrounding RIFF/WAVE/data framing. RF64 needs this in isolation, since
+// its ds64 chunk sits between the WAVE tag and the fmt chunk.
+func (h *wavfileHeader) fmtChunkBytes() []byte {
+	buffer := new(bytes.Buffer)
+	buffer.Write(h.fmtTag[:])
+	binary.Write(buffer, binary.LittleEndian, h.fmtLength)
+	binary.Write(buffer, binary.LittleEndian, h.audioFormat)
+	binary.Write(buffer, binary.LittleEndian, h.numChannels)
+	binary.Write(buffer, binary.LittleEndian, h.sampleRate)
+	binary.Write(buffer, binary.LittleEndian, h.byteRate)
+	binary.Write(buffer, binary.LittleEndian, h.blockAlign)
+	binary.Write(buffer, binary.LittleEndian, h.bitsPerSample)
+	buffer.Write(h.extension)
+	return buffer.Bytes()
+}
+
+// Convert the wavfileHeader struct to a byte slice.
 func (h *wavfileHeader) Bytes() []byte {
 	buffer := new(bytes.Buffer)
-	binary.Write(buffer, binary.LittleEndian, h)
+	buffer.Write(h.riffTag[:])
+	binary.Write(buffer, binary.LittleEndian, h.riffLength)
+	buffer.Write(h.waveTag[:])
+	buffer.Write(h.fmtChunkBytes())
+	buffer.Write(h.dataTag[:])
+	binary.Write(buffer, binary.LittleEndian, h.dataLength)
 	return buffer.Bytes()
 }
 
-// Create a file and return it for further writing of audio data.
-func New(w io.WriterAt, samplesPerSecond uint32, bitsPerSample uint8, channels uint16, waveform []byte) error {
+// Create a file and return it for further writing of audio data. mode
+// controls promotion to RF64 for files whose size would overflow the
+// 32-bit RIFF/data fields; it defaults to RF64Auto if omitted.
+func New(w io.WriterAt, samplesPerSecond uint32, bitsPerSample uint8, channels uint16, waveform []byte, mode ...RF64Mode) error {
 	header := newWavfileHeader(samplesPerSecond, bitsPerSample, channels)
+	return writeWavFile(w, header, waveform, rf64ModeOrDefault(mode))
+}
+
+// rf64ModeOrDefault returns the single mode in modes, or RF64Auto if modes
+// is empty, for the New/NewFloat32/NewExtensible family's optional
+// trailing RF64Mode argument.
+func rf64ModeOrDefault(modes []RF64Mode) RF64Mode {
+	if len(modes) > 0 {
+		return modes[0]
+	}
+	return RF64Auto
+}
+
+// writeWavFile writes header followed by waveform to w as a plain RIFF/WAVE
+// file, or as RF64 per mode, then backpatches the riffLength and dataLength
+// fields once the final size is known. The data offset and the dataLength
+// field's offset are derived from the header's actual (possibly extended)
+// size rather than assumed to be 44.
+func writeWavFile(w io.WriterAt, header *wavfileHeader, waveform []byte, mode RF64Mode) error {
+	if mode == RF64Always || (mode == RF64Auto && needsRF64(header.fmtChunkBytes(), uint64(len(waveform)))) {
+		return writeRF64File(w, header, waveform)
+	}
+
+	headerBytes := header.Bytes()
+	wavfileHeaderSize := uint32(len(headerBytes))
 
 	var size uint32
 
-	written, err := w.WriteAt(header.Bytes(), 0)
+	written, err := w.WriteAt(headerBytes, 0)
 	size += uint32(written)
 	if err != nil {
 		return err
 	}
 
-	// Write the data starting at offset 44, which is the first offset after the header.
-	written, err = w.WriteAt(waveform, 44)
+	written, err = w.WriteAt(waveform, int64(wavfileHeaderSize))
 	size += uint32(written)
 	if err != nil {
 		return err
 	}
 
 	var (
-		wavfileHeaderSize uint32 = 44 // bytes
-		riffLength        uint32 = size - 8
-		dataLength        uint32 = size - wavfileHeaderSize
+		riffLength uint32 = size - 8
+		dataLength uint32 = size - wavfileHeaderSize
 	)
 
 	// Write the riffLength into the header
@@ -132,10 +183,10 @@ func New(w io.WriterAt, samplesPerSecond uint32, bitsPerSample uint8, channels u
 		return err
 	}
 
-	// Write the length of the file into the header
-	// The dataLength header starts at offset 40
+	// Write the dataLength into the header; dataLength is always the last
+	// 4 bytes of the header, immediately after dataTag.
 	dl := make([]byte, 4)
 	binary.LittleEndian.PutUint32(dl, dataLength)
-	_, err = w.WriteAt(dl, 40)
+	_, err = w.WriteAt(dl, int64(wavfileHeaderSize-4))
 	return err
 }
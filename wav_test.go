@@ -0,0 +1,54 @@
+package wav
+
+import "io"
+
+// memWriterAt is an in-memory sink that implements io.Writer, io.WriterAt
+// and io.Seeker, so tests can exercise both the sequential-write path and
+// the backpatch-on-Close paths in Writer and New.
+type memWriterAt struct {
+	buf []byte
+	pos int64
+}
+
+func (m *memWriterAt) Write(p []byte) (int, error) {
+	n, err := m.WriteAt(p, m.pos)
+	m.pos += int64(n)
+	return n, err
+}
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	if end > int64(len(m.buf)) {
+		grown := make([]byte, end)
+		copy(grown, m.buf)
+		m.buf = grown
+	}
+	copy(m.buf[off:], p)
+	return len(p), nil
+}
+
+func (m *memWriterAt) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		m.pos = offset
+	case io.SeekCurrent:
+		m.pos += offset
+	case io.SeekEnd:
+		m.pos = int64(len(m.buf)) + offset
+	}
+	return m.pos, nil
+}
+
+// seekWriter is like memWriterAt but without WriteAt, so tests can exercise
+// the io.Seeker-only fallback paths.
+type seekWriter struct {
+	mem memWriterAt
+}
+
+func (s *seekWriter) Write(p []byte) (int, error) {
+	return s.mem.Write(p)
+}
+
+func (s *seekWriter) Seek(offset int64, whence int) (int64, error) {
+	return s.mem.Seek(offset, whence)
+}